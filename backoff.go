@@ -0,0 +1,148 @@
+package cmder
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// BackoffPolicy decides how long to sleep between retry attempts.
+type BackoffPolicy interface {
+	// NextDelay returns how long to sleep before the next attempt. attempt is
+	// the zero-based index of the attempt that just failed, and lastErr is the
+	// error it failed with.
+	NextDelay(attempt int, lastErr error) time.Duration
+}
+
+// ConstantBackoff always waits the same fixed Delay between attempts.
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+func (b ConstantBackoff) NextDelay(_ int, _ error) time.Duration {
+	return b.Delay
+}
+
+// ExponentialBackoff grows the delay as Base*Factor^attempt, capped at Max,
+// then applies full jitter: a uniform random delay is drawn from
+// [cap*(1-Jitter), cap]. Jitter=0 disables randomization entirely (always
+// sleeps the capped exponential delay); Jitter=1 is classic "full jitter"
+// (uniform in [0, cap]).
+type ExponentialBackoff struct {
+	Base   time.Duration
+	Max    time.Duration
+	Factor float64
+	Jitter float64
+}
+
+func (b ExponentialBackoff) NextDelay(attempt int, _ error) time.Duration {
+	capped := float64(b.Base) * math.Pow(b.Factor, float64(attempt))
+	if b.Max > 0 && capped > float64(b.Max) {
+		capped = float64(b.Max)
+	}
+	if capped < 0 {
+		capped = 0
+	}
+
+	jitter := b.Jitter
+	switch {
+	case jitter < 0:
+		jitter = 0
+	case jitter > 1:
+		jitter = 1
+	}
+
+	floor := capped * (1 - jitter)
+	spread := capped * jitter
+
+	delay := floor
+	if spread > 0 {
+		delay += rand.Float64() * spread
+	}
+
+	return time.Duration(delay)
+}
+
+// DecorrelatedJitterBackoff implements the "decorrelated jitter" policy
+// (https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/):
+// each delay is drawn uniformly from [Base, prevDelay*3], capped at Max. Use
+// NewDecorrelatedJitterBackoff to construct one, since it tracks state between
+// calls.
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+
+	mu   sync.Mutex
+	prev time.Duration
+}
+
+func NewDecorrelatedJitterBackoff(base time.Duration, max time.Duration) *DecorrelatedJitterBackoff {
+	return &DecorrelatedJitterBackoff{Base: base, Max: max}
+}
+
+func (b *DecorrelatedJitterBackoff) NextDelay(_ int, _ error) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	prev := b.prev
+	if prev <= 0 {
+		prev = b.Base
+	}
+
+	upper := float64(prev) * 3
+	if upper < float64(b.Base) {
+		upper = float64(b.Base)
+	}
+
+	delay := time.Duration(float64(b.Base) + rand.Float64()*(upper-float64(b.Base)))
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+
+	b.prev = delay
+	return delay
+}
+
+// AttemptResult summarizes a single attempt, passed to Spec's OnAttempt
+// callback after the attempt finishes.
+type AttemptResult struct {
+	Attempt    int
+	Elapsed    time.Duration
+	ExitCode   int
+	Err        error
+	StdOutTail string
+	StdErrTail string
+}
+
+// tailWriter keeps only the last max bytes written to it, so OnAttempt can
+// report a bounded tail of output even when CollectAllOutput is false.
+type tailWriter struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+const defaultAttemptTailSize = 4096
+
+func newTailWriter() *tailWriter {
+	return &tailWriter{max: defaultAttemptTailSize}
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = t.buf[len(t.buf)-t.max:]
+	}
+
+	return len(p), nil
+}
+
+func (t *tailWriter) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
@@ -10,8 +10,11 @@ import (
 	"log/slog"
 	"os"
 	"os/exec"
+	"os/signal"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 )
 
@@ -28,6 +31,12 @@ type Spec struct {
 	ResetAttemptTimeoutOnOutput bool
 	Retries                     int
 	RetryFilter                 func(err error, isAttemptTimeout bool) bool
+	Backoff                     BackoffPolicy
+	OnAttempt                   func(attempt int, res AttemptResult)
+
+	// Graceful termination
+	KillSteps        []KillStep
+	InterruptSignals []os.Signal
 
 	// Input/Output
 	StdIn            io.Reader
@@ -35,17 +44,74 @@ type Spec struct {
 	StdErr           io.Writer // if capturing output while running
 	CollectAllOutput bool      // if running for a very long time, set this false to avoid OOM
 
+	// Streaming line-oriented output. Each channel is closed exactly once, when
+	// Run has no attempts left - don't reuse the same channel across Run calls.
+	// Combine with CollectAllOutput=false to drive purely streaming workloads
+	// without the memory cost of buffering the whole output.
+	StdOutLines   chan<- string
+	StdErrLines   chan<- string
+	CombinedLines chan<- string
+
 	// debug functionality
 	Verbose bool
 }
 
 type Result struct {
-	StdOut   string
-	StdErr   string
-	Combined string
-	Err      error
-	Attempts int
-	ExitCode int
+	StdOut       string
+	StdErr       string
+	Combined     string
+	Err          error
+	Attempts     int
+	ExitCode     int
+	ExitClass    ExitClass // classification of Err, see ClassifyExitError
+	TerminatedBy os.Signal // set if the kill escalation had to signal the process
+
+	// Structured exit-status classification, populated from the last attempt
+	Signaled bool
+	Signal   syscall.Signal
+	CoreDump bool
+}
+
+// KillStep is a single step of a kill escalation: Signal is sent to the process
+// (or its process group, see setupProcessGroup/sendSignal), and if it hasn't
+// exited within Wait, the next step runs.
+type KillStep struct {
+	Signal os.Signal
+	Wait   time.Duration
+}
+
+// signalBox lets withRetries report back which signal (if any) it had to use
+// to terminate the process, without racing with Run reading it afterward.
+type signalBox struct {
+	mu  sync.Mutex
+	sig os.Signal
+}
+
+func (b *signalBox) set(sig os.Signal) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sig = sig
+}
+
+func (b *signalBox) get() os.Signal {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sig
+}
+
+// syncWriter serializes writes to w with a mutex, for writers (like
+// bytes.Buffer) with no locking of their own that end up shared between
+// concurrent goroutines, e.g. the stdout/stderr copier goroutines os/exec
+// spawns internally both writing into the same combined-output buffer.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
 }
 
 func New(appAndArgs ...string) Spec {
@@ -167,6 +233,21 @@ func (c Spec) WithRetries(n int) Spec {
 	return c
 }
 
+// WithBackoff sets the policy used to wait between retry attempts. With no
+// policy set, retries happen back-to-back with no delay.
+func (c Spec) WithBackoff(policy BackoffPolicy) Spec {
+	c.Backoff = policy
+	return c
+}
+
+// WithOnAttempt sets a callback invoked after each attempt (including the
+// final one) with the attempt number and a summary of how it went, so callers
+// can log or emit metrics between retries.
+func (c Spec) WithOnAttempt(onAttempt func(attempt int, res AttemptResult)) Spec {
+	c.OnAttempt = onAttempt
+	return c
+}
+
 // WithVerbose sets the verbose flag
 func (c Spec) WithVerbose(verbose bool) Spec {
 	c.Verbose = verbose
@@ -180,6 +261,51 @@ func (c Spec) WithAttemptTimeout(timeout time.Duration) Spec {
 	return c
 }
 
+// WithGracefulShutdown configures a single-step kill escalation: on attempt
+// timeout or ctx cancellation, signal is sent to the process (group) first, and
+// if it hasn't exited within grace, it is force-killed with SIGKILL.
+func (c Spec) WithGracefulShutdown(signal os.Signal, grace time.Duration) Spec {
+	c.KillSteps = []KillStep{{Signal: signal, Wait: grace}}
+	return c
+}
+
+// WithKillEscalation configures a custom, ordered kill escalation. Each step's
+// signal is sent, and if the process is still alive after step.Wait, the next
+// step runs; if all steps are exhausted the process is force-killed with SIGKILL.
+func (c Spec) WithKillEscalation(steps []KillStep) Spec {
+	c.KillSteps = steps
+	return c
+}
+
+// WithInterruptOn forwards any of the given signals received by this process to
+// the running child (instead of triggering the kill escalation), for as long as
+// an attempt is in flight.
+func (c Spec) WithInterruptOn(sigs ...os.Signal) Spec {
+	c.InterruptSignals = sigs
+	return c
+}
+
+// WithStdOutLines delivers each line of stdout over ch as it is produced,
+// instead of (or in addition to) buffering it into Result.StdOut.
+func (c Spec) WithStdOutLines(ch chan<- string) Spec {
+	c.StdOutLines = ch
+	return c
+}
+
+// WithStdErrLines delivers each line of stderr over ch as it is produced,
+// instead of (or in addition to) buffering it into Result.StdErr.
+func (c Spec) WithStdErrLines(ch chan<- string) Spec {
+	c.StdErrLines = ch
+	return c
+}
+
+// WithStdOutErrLines delivers each line of combined stdout+stderr over ch, in
+// the order it was produced, as it is produced.
+func (c Spec) WithStdOutErrLines(ch chan<- string) Spec {
+	c.CombinedLines = ch
+	return c
+}
+
 func (c Spec) logBeforeRun() {
 	if c.Verbose {
 		slog.Info(fmt.Sprintf("%s$ %s %s\n", c.WorkingDirectory, c.App, strings.Join(c.Args, " ")))
@@ -208,11 +334,15 @@ func (c Spec) Run(ctx context.Context) Result {
 	stderrBuffer := &bytes.Buffer{}
 	combinedBuffer := &bytes.Buffer{}
 	attempts := 0
-	exitCode := 0
+	result := Result{}
+	terminatedBy := &signalBox{}
 
-	err := c.withRetries(ctx, func(cmd *exec.Cmd, aliveChannel chan any) error {
+	err := c.withRetries(ctx, terminatedBy, func(cmd *exec.Cmd, aliveChannel chan any, started chan<- struct{}) error {
 
-		exitCode = 0
+		result.ExitCode = 0
+		result.Signaled = false
+		result.Signal = 0
+		result.CoreDump = false
 
 		// Reset these each time, because they could internally
 		attempts++
@@ -230,8 +360,13 @@ func (c Spec) Run(ctx context.Context) Result {
 			stdoutBuffer = &bytes.Buffer{}
 			stderrBuffer = &bytes.Buffer{}
 			combinedBuffer = &bytes.Buffer{}
-			stdOutTargets = append(stdOutTargets, stdoutBuffer, combinedBuffer)
-			stdErrTargets = append(stdErrTargets, stderrBuffer, combinedBuffer)
+			// combinedBuffer is written from both the stdout and stderr copier
+			// goroutines os/exec spawns internally, so - unlike stdoutBuffer and
+			// stderrBuffer, which each only ever see one of those goroutines -
+			// it needs its own synchronization; bytes.Buffer has none.
+			combinedWriter := &syncWriter{w: combinedBuffer}
+			stdOutTargets = append(stdOutTargets, stdoutBuffer, combinedWriter)
+			stdErrTargets = append(stdErrTargets, stderrBuffer, combinedWriter)
 		}
 
 		// If we are capturing output, we need to write to the corresponding writers
@@ -242,36 +377,98 @@ func (c Spec) Run(ctx context.Context) Result {
 			stdErrTargets = append(stdErrTargets, c.StdErr)
 		}
 
+		// Fresh line forwarders every attempt: each gets its own internal pipe
+		// and scanner goroutine, so a retry never writes into a prior attempt's
+		// (already closed) pipe.
+		var stdOutLineFwd, stdErrLineFwd, combinedLineFwd *lineForwarder
+		if c.StdOutLines != nil {
+			stdOutLineFwd = newLineForwarder(c.StdOutLines)
+			stdOutTargets = append(stdOutTargets, stdOutLineFwd)
+		}
+		if c.StdErrLines != nil {
+			stdErrLineFwd = newLineForwarder(c.StdErrLines)
+			stdErrTargets = append(stdErrTargets, stdErrLineFwd)
+		}
+		if c.CombinedLines != nil {
+			combinedLineFwd = newLineForwarder(c.CombinedLines)
+			stdOutTargets = append(stdOutTargets, combinedLineFwd)
+			stdErrTargets = append(stdErrTargets, combinedLineFwd)
+		}
+
+		// Tails are captured whenever OnAttempt is set, regardless of
+		// CollectAllOutput (cheap, bounded), so OnAttempt has something to
+		// report even with CollectAllOutput=false.
+		var stdOutTail, stdErrTail *tailWriter
+		if c.OnAttempt != nil {
+			stdOutTail = newTailWriter()
+			stdErrTail = newTailWriter()
+			stdOutTargets = append(stdOutTargets, stdOutTail)
+			stdErrTargets = append(stdErrTargets, stdErrTail)
+		}
+
 		// Set the writers
 		cmd.Stdout = io.MultiWriter(stdOutTargets...)
 		cmd.Stderr = io.MultiWriter(stdErrTargets...)
 
-		err := cmd.Run() // waits internally
+		attemptStart := time.Now()
 
-		if err != nil {
-			if cmd.ProcessState != nil {
-				exitCode = cmd.ProcessState.ExitCode()
-			} else {
-				exitCode = -1
+		// cmd.Start() is split out from cmd.Wait() (rather than using cmd.Run())
+		// so we can close(started) right after it returns - that's the
+		// happens-before edge the kill-escalation/interrupt-forwarding watchers
+		// in withRetries rely on before they touch cmd.Process.
+		startErr := cmd.Start()
+		close(started)
+
+		var err error
+		if startErr != nil {
+			err = startErr
+		} else {
+			err = cmd.Wait()
+		}
+
+		for _, fwd := range []*lineForwarder{stdOutLineFwd, stdErrLineFwd, combinedLineFwd} {
+			if fwd != nil {
+				fwd.close()
+			}
+		}
+
+		populateExitStatus(&result, cmd, err)
+
+		if c.OnAttempt != nil {
+			attemptResult := AttemptResult{
+				Attempt:  attempts,
+				Elapsed:  time.Since(attemptStart),
+				ExitCode: result.ExitCode,
+				Err:      err,
+			}
+			if stdOutTail != nil {
+				attemptResult.StdOutTail = stdOutTail.String()
+			}
+			if stdErrTail != nil {
+				attemptResult.StdErrTail = stdErrTail.String()
 			}
+			c.OnAttempt(attempts, attemptResult)
 		}
 
 		return err
 
 	})
 
-	stdout := stdoutBuffer.String()
-	stderr := stderrBuffer.String()
-	combined := combinedBuffer.String()
-
-	return Result{
-		StdOut:   stdout,
-		StdErr:   stderr,
-		Combined: combined,
-		Err:      err,
-		Attempts: attempts,
-		ExitCode: exitCode,
+	result.StdOut = stdoutBuffer.String()
+	result.StdErr = stderrBuffer.String()
+	result.Combined = combinedBuffer.String()
+	result.Err = err
+	result.ExitClass = ClassifyExitError(err)
+	result.Attempts = attempts
+	result.TerminatedBy = terminatedBy.get()
+
+	for _, ch := range []chan<- string{c.StdOutLines, c.StdErrLines, c.CombinedLines} {
+		if ch != nil {
+			close(ch)
+		}
 	}
+
+	return result
 }
 
 func executeAfterDuration(ctx context.Context, duration time.Duration, task func()) {
@@ -288,7 +485,24 @@ func toPtr[T any](x T) *T {
 	return &x
 }
 
-func (c Spec) withRetries(parentCtx context.Context, processor func(cmd *exec.Cmd, aliveSignal chan any) error) error {
+// escalate runs the configured KillSteps against cmd, in order, until the
+// process exits or the steps are exhausted, at which point it is force-killed
+// with SIGKILL. It returns the signal that was in flight when the process was
+// last observed to still be alive (or os.Kill, if we had to fall back to it).
+func (c Spec) escalate(cmd *exec.Cmd, processDone <-chan struct{}) os.Signal {
+	for _, step := range c.KillSteps {
+		_ = sendSignal(cmd, step.Signal)
+		select {
+		case <-processDone:
+			return step.Signal
+		case <-time.After(step.Wait):
+		}
+	}
+	_ = sendSignal(cmd, os.Kill)
+	return os.Kill
+}
+
+func (c Spec) withRetries(parentCtx context.Context, terminatedBy *signalBox, processor func(cmd *exec.Cmd, aliveSignal chan any, started chan<- struct{}) error) error {
 
 	c.logBeforeRun()
 
@@ -304,6 +518,8 @@ func (c Spec) withRetries(parentCtx context.Context, processor func(cmd *exec.Cm
 		})
 	}
 
+	lastAttemptTimedOut := false
+
 	for i := 0; i <= c.Retries; i++ {
 
 		attemptTimedOut := atomic.Bool{}
@@ -347,25 +563,103 @@ func (c Spec) withRetries(parentCtx context.Context, processor func(cmd *exec.Cm
 				}
 			}()
 
-			cmd := exec.CommandContext(attemptCtx, c.App, c.Args...)
+			// cmd is intentionally not created with exec.CommandContext: we want
+			// attemptCtx cancellation (timeout, total timeout, parent ctx) to run
+			// our own kill escalation below, rather than exec's default immediate
+			// SIGKILL-on-cancel behavior.
+			cmd := exec.Command(c.App, c.Args...)
 			cmd.Dir = c.WorkingDirectory
 
-			return processor(cmd, aliveSignal)
+			// Only opt the child into its own process group when kill escalation
+			// or signal forwarding is actually configured - putting every child
+			// in a new group unconditionally would silently stop it from
+			// receiving signals sent to our own process group (e.g. Ctrl-C),
+			// breaking existing callers that never asked for either feature.
+			if len(c.KillSteps) > 0 || len(c.InterruptSignals) > 0 {
+				setupProcessGroup(cmd)
+			}
+
+			processDone := make(chan struct{})
+			// started is closed by processor right after cmd.Start() returns,
+			// whether or not it succeeded. cmd.Process is written by Start() from inside
+			// processor's goroutine - nothing below may read it until that
+			// happens-before edge is established via this channel, or we'd
+			// have a data race between that write and escalate/sendSignal.
+			started := make(chan struct{})
+			var watcherWg sync.WaitGroup
+
+			watcherWg.Add(1)
+			go func() {
+				defer watcherWg.Done()
+				select {
+				case <-processDone:
+					return
+				case <-started:
+				}
+				select {
+				case <-processDone:
+					return
+				case <-attemptCtx.Done():
+				}
+				select {
+				case <-processDone:
+					return
+				default:
+				}
+				terminatedBy.set(c.escalate(cmd, processDone))
+			}()
+
+			if len(c.InterruptSignals) > 0 {
+				sigCh := make(chan os.Signal, 1)
+				signal.Notify(sigCh, c.InterruptSignals...)
+
+				watcherWg.Add(1)
+				go func() {
+					defer watcherWg.Done()
+					defer signal.Stop(sigCh)
+					select {
+					case <-processDone:
+						return
+					case <-started:
+					}
+					for {
+						select {
+						case sig := <-sigCh:
+							_ = sendSignal(cmd, sig)
+						case <-processDone:
+							return
+						}
+					}
+				}()
+			}
+
+			defer watcherWg.Wait()
+
+			err := processor(cmd, aliveSignal, started)
+			close(processDone)
+			return err
 
 		}()
 
+		lastAttemptTimedOut = attemptTimedOut.Load()
+
 		if err != nil {
 			if c.RetryFilter(err, attemptTimedOut.Load()) {
 				if c.Verbose {
 					slog.Warn(fmt.Sprintf("retrying %s, attempt %d/%d \n", c.App, i+1, c.Retries+1))
 				}
+				// Only sleep if another attempt will actually follow - on the
+				// last iteration we're about to report final failure, so a
+				// backoff sleep here would just be needless latency.
+				if c.Backoff != nil && i < c.Retries {
+					select {
+					case <-time.After(c.Backoff.NextDelay(i, err)):
+					case <-jobCtx.Done():
+					}
+				}
 				continue
 			} else {
-				if jobTimedOut.Load() {
-					return fmt.Errorf("error running cmd %s \n %s: %w", c.App, "timeout", context.DeadlineExceeded)
-				} else {
-					return fmt.Errorf("error running cmd %s \n %s: %w", c.App, err.Error(), err)
-				}
+				return c.wrapRunErr(err, jobTimedOut.Load(), attemptTimedOut.Load(), parentCtx)
 			}
 		}
 
@@ -373,5 +667,43 @@ func (c Spec) withRetries(parentCtx context.Context, processor func(cmd *exec.Cm
 
 	}
 
-	return fmt.Errorf("error running cmd %s \n %s: %w", c.App, "timeout and max retries exceeded", context.DeadlineExceeded)
+	switch {
+	case jobTimedOut.Load():
+		wrapped := fmt.Errorf("%w: %w", context.DeadlineExceeded, ErrTotalTimeout)
+		exitErr := &ExitError{Cause: wrapped, Class: ExitTotalTimeout}
+		return fmt.Errorf("error running cmd %s \n %s: %w", c.App, "timeout and max retries exceeded", exitErr)
+	case lastAttemptTimedOut:
+		wrapped := fmt.Errorf("%w: %w", context.DeadlineExceeded, ErrAttemptTimeout)
+		exitErr := &ExitError{Cause: wrapped, Class: ExitAttemptTimeout}
+		return fmt.Errorf("error running cmd %s \n %s: %w", c.App, "timeout and max retries exceeded", exitErr)
+	default:
+		return fmt.Errorf("error running cmd %s \n %s: %w", c.App, "timeout and max retries exceeded", context.DeadlineExceeded)
+	}
+}
+
+// wrapRunErr builds the final error returned from withRetries, multi-wrapping
+// sentinel errors on top of cause so callers can use errors.Is/As to tell
+// command-not-found, attempt-timeout, total-timeout and context-canceled apart,
+// instead of string-matching.
+func (c Spec) wrapRunErr(cause error, jobTimedOut bool, attemptTimedOut bool, parentCtx context.Context) error {
+	switch {
+	case jobTimedOut:
+		wrapped := fmt.Errorf("%w: %w", context.DeadlineExceeded, ErrTotalTimeout)
+		exitErr := &ExitError{Cause: wrapped, Class: ExitTotalTimeout}
+		return fmt.Errorf("error running cmd %s \n %s: %w", c.App, "timeout", exitErr)
+	case attemptTimedOut:
+		wrapped := fmt.Errorf("%w: %w", context.DeadlineExceeded, ErrAttemptTimeout)
+		exitErr := &ExitError{Cause: wrapped, Class: ExitAttemptTimeout}
+		return fmt.Errorf("error running cmd %s \n %s: %w", c.App, "timeout", exitErr)
+	case errors.Is(parentCtx.Err(), context.Canceled):
+		exitErr := &ExitError{Cause: context.Canceled, Class: ExitContextCanceled}
+		return fmt.Errorf("error running cmd %s \n %s: %w", c.App, "context canceled", exitErr)
+	case ClassifyExitError(cause) == ExitCommandNotFound:
+		wrapped := fmt.Errorf("%w: %w", cause, ErrCommandNotFound)
+		exitErr := &ExitError{Cause: wrapped, Class: ExitCommandNotFound}
+		return fmt.Errorf("error running cmd %s \n %s: %w", c.App, cause.Error(), exitErr)
+	default:
+		exitErr := &ExitError{Cause: cause, Class: ClassifyExitError(cause)}
+		return fmt.Errorf("error running cmd %s \n %s: %w", c.App, cause.Error(), exitErr)
+	}
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -251,3 +253,227 @@ func TestCommand_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestCommand_GracefulShutdown(t *testing.T) {
+	result := New("bash", "-c", `trap 'echo got term; exit 7' TERM; sleep 10`).
+		WithAttemptTimeout(500 * time.Millisecond).
+		WithGracefulShutdown(syscall.SIGTERM, 2*time.Second).
+		Run(context.Background())
+
+	if result.TerminatedBy != syscall.SIGTERM {
+		t.Errorf("expected TerminatedBy to be SIGTERM, got %v", result.TerminatedBy)
+	}
+	if !strings.Contains(result.StdOut, "got term") {
+		t.Errorf("expected child to have run its TERM trap, got StdOut %q", result.StdOut)
+	}
+}
+
+func TestCommand_ExitClassification(t *testing.T) {
+	t.Run("command not found", func(t *testing.T) {
+		result := New("abc123").WithAttemptTimeout(1 * time.Second).Run(context.Background())
+
+		if !errors.Is(result.Err, ErrCommandNotFound) {
+			t.Errorf("expected errors.Is(err, ErrCommandNotFound), got %v", result.Err)
+		}
+		if result.ExitCode != 127 {
+			t.Errorf("expected ExitCode 127, got %d", result.ExitCode)
+		}
+	})
+
+	t.Run("non-zero exit", func(t *testing.T) {
+		result := New("false").WithAttemptTimeout(1 * time.Second).Run(context.Background())
+
+		var exitErr *ExitError
+		if !errors.As(result.Err, &exitErr) {
+			t.Fatalf("expected errors.As to find an *ExitError, got %v", result.Err)
+		}
+		if exitErr.Class != ExitNonZero {
+			t.Errorf("expected ExitClass ExitNonZero, got %v", exitErr.Class)
+		}
+		if result.Signaled {
+			t.Error("expected Signaled to be false")
+		}
+	})
+
+	t.Run("signaled", func(t *testing.T) {
+		result := New("bash", "-c", "kill -TERM $$").WithAttemptTimeout(1 * time.Second).Run(context.Background())
+
+		if !result.Signaled {
+			t.Error("expected Signaled to be true")
+		}
+		if result.Signal != syscall.SIGTERM {
+			t.Errorf("expected Signal SIGTERM, got %v", result.Signal)
+		}
+	})
+
+	t.Run("attempt timeout vs total timeout", func(t *testing.T) {
+		attemptResult := New("sleep", "10").WithAttemptTimeout(200 * time.Millisecond).Run(context.Background())
+		if !errors.Is(attemptResult.Err, ErrAttemptTimeout) {
+			t.Errorf("expected errors.Is(err, ErrAttemptTimeout), got %v", attemptResult.Err)
+		}
+		if errors.Is(attemptResult.Err, ErrTotalTimeout) {
+			t.Error("did not expect errors.Is(err, ErrTotalTimeout)")
+		}
+		if attemptResult.ExitClass != ExitAttemptTimeout {
+			t.Errorf("expected ExitClass ExitAttemptTimeout, got %v", attemptResult.ExitClass)
+		}
+		var attemptExitErr *ExitError
+		if !errors.As(attemptResult.Err, &attemptExitErr) {
+			t.Fatalf("expected errors.As to find an *ExitError, got %v", attemptResult.Err)
+		}
+		if attemptExitErr.Class != ExitAttemptTimeout {
+			t.Errorf("expected ExitError.Class ExitAttemptTimeout, got %v", attemptExitErr.Class)
+		}
+
+		totalResult := New("sleep", "10").WithTotalTimeout(200 * time.Millisecond).Run(context.Background())
+		if !errors.Is(totalResult.Err, ErrTotalTimeout) {
+			t.Errorf("expected errors.Is(err, ErrTotalTimeout), got %v", totalResult.Err)
+		}
+		if errors.Is(totalResult.Err, ErrAttemptTimeout) {
+			t.Error("did not expect errors.Is(err, ErrAttemptTimeout)")
+		}
+		if totalResult.ExitClass != ExitTotalTimeout {
+			t.Errorf("expected ExitClass ExitTotalTimeout, got %v", totalResult.ExitClass)
+		}
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result := New("sleep", "10").Run(ctx)
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("expected errors.Is(err, context.Canceled), got %v", result.Err)
+		}
+		if result.ExitClass != ExitContextCanceled {
+			t.Errorf("expected ExitClass ExitContextCanceled, got %v", result.ExitClass)
+		}
+	})
+}
+
+func TestCommand_StdOutLines(t *testing.T) {
+	lines := make(chan string, 10)
+
+	result := New("bash", "-c", "echo one; echo two; echo three").
+		WithAttemptTimeout(5 * time.Second).
+		WithStdOutLines(lines).
+		Run(context.Background())
+
+	if result.Err != nil {
+		t.Fatalf("Run() error: %v", result.Err)
+	}
+
+	var got []string
+	for line := range lines {
+		got = append(got, line)
+	}
+
+	want := []string{"one", "two", "three"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected line %d to be %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestCommand_StdOutErrLinesCombined(t *testing.T) {
+	lines := make(chan string, 10)
+
+	result := New("bash", "-c", "echo out1; echo err1 >&2; echo out2").
+		WithAttemptTimeout(5 * time.Second).
+		WithStdOutErrLines(lines).
+		Run(context.Background())
+
+	if result.Err != nil {
+		t.Fatalf("Run() error: %v", result.Err)
+	}
+
+	count := 0
+	for range lines {
+		count++
+	}
+	if count != 3 {
+		t.Errorf("expected 3 combined lines, got %d", count)
+	}
+}
+
+func TestCommand_BackoffAndOnAttempt(t *testing.T) {
+	var attemptNumbers []int
+	var elapsed []time.Duration
+
+	start := time.Now()
+	result := New("false").
+		WithRetries(2).
+		WithAttemptTimeout(1 * time.Second).
+		WithRetryFilter(func(err error, isAttemptTimeout bool) bool { return true }).
+		WithBackoff(ConstantBackoff{Delay: 100 * time.Millisecond}).
+		WithOnAttempt(func(attempt int, res AttemptResult) {
+			attemptNumbers = append(attemptNumbers, attempt)
+			elapsed = append(elapsed, res.Elapsed)
+		}).
+		Run(context.Background())
+
+	if result.Attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", result.Attempts)
+	}
+	if len(attemptNumbers) != 3 {
+		t.Fatalf("expected OnAttempt called 3 times, got %d", len(attemptNumbers))
+	}
+	for i, n := range attemptNumbers {
+		if n != i+1 {
+			t.Errorf("expected attempt number %d, got %d", i+1, n)
+		}
+	}
+
+	if time.Since(start) < 200*time.Millisecond {
+		t.Errorf("expected at least 2x100ms of backoff delay between the 3 attempts")
+	}
+}
+
+func TestCommand_BackoffSkipsSleepOnExhaustedRetries(t *testing.T) {
+	start := time.Now()
+	result := New("false").
+		WithRetries(1).
+		WithRetryFilter(func(err error, isAttemptTimeout bool) bool { return true }).
+		WithBackoff(ConstantBackoff{Delay: 2 * time.Second}).
+		Run(context.Background())
+
+	if result.Attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", result.Attempts)
+	}
+	// One retry means one backoff sleep (between attempt 1 and 2), not two -
+	// there's no attempt left to wait for after the last one fails.
+	if elapsed := time.Since(start); elapsed >= 4*time.Second {
+		t.Errorf("expected only one backoff sleep (~2s), took %v - backoff ran after the last exhausted attempt too", elapsed)
+	}
+}
+
+func TestExponentialBackoff_NextDelay(t *testing.T) {
+	b := ExponentialBackoff{Base: 10 * time.Millisecond, Max: 100 * time.Millisecond, Factor: 2, Jitter: 0}
+
+	if got := b.NextDelay(0, nil); got != 10*time.Millisecond {
+		t.Errorf("expected 10ms at attempt 0, got %v", got)
+	}
+	if got := b.NextDelay(3, nil); got != 80*time.Millisecond {
+		t.Errorf("expected 80ms at attempt 3, got %v", got)
+	}
+	if got := b.NextDelay(10, nil); got != 100*time.Millisecond {
+		t.Errorf("expected delay capped at 100ms, got %v", got)
+	}
+}
+
+func TestCommand_KillEscalation(t *testing.T) {
+	result := New("bash", "-c", `trap '' TERM; sleep 10`).
+		WithAttemptTimeout(300 * time.Millisecond).
+		WithKillEscalation([]KillStep{
+			{Signal: syscall.SIGTERM, Wait: 300 * time.Millisecond},
+		}).
+		Run(context.Background())
+
+	if result.TerminatedBy != os.Kill {
+		t.Errorf("expected TerminatedBy to fall back to os.Kill once SIGTERM was ignored, got %v", result.TerminatedBy)
+	}
+}
@@ -0,0 +1,129 @@
+package cmder
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// ExitClass categorizes why a Spec.Run invocation ended the way it did, so
+// callers don't have to string-match on Result.Err/error messages.
+type ExitClass int
+
+const (
+	ExitUnknown ExitClass = iota
+	ExitSuccess
+	ExitCommandNotFound
+	ExitSignaled
+	ExitNonZero
+	ExitAttemptTimeout
+	ExitTotalTimeout
+	ExitContextCanceled
+)
+
+func (c ExitClass) String() string {
+	switch c {
+	case ExitSuccess:
+		return "success"
+	case ExitCommandNotFound:
+		return "command not found"
+	case ExitSignaled:
+		return "signaled"
+	case ExitNonZero:
+		return "non-zero exit"
+	case ExitAttemptTimeout:
+		return "attempt timeout"
+	case ExitTotalTimeout:
+		return "total timeout"
+	case ExitContextCanceled:
+		return "context canceled"
+	default:
+		return "unknown"
+	}
+}
+
+// Sentinel errors so callers can use errors.Is(result.Err, cmder.ErrXxx) instead
+// of string-matching, e.g. errors.Is(err, cmder.ErrAttemptTimeout).
+var (
+	ErrCommandNotFound = errors.New("cmder: command not found")
+	ErrAttemptTimeout  = errors.New("cmder: attempt timeout")
+	ErrTotalTimeout    = errors.New("cmder: total timeout")
+)
+
+// ExitError wraps the cause of a failed Spec.Run (typically an *exec.ExitError,
+// but also an attempt/total timeout or context-cancellation error) together
+// with its ExitClass. errors.As/errors.Is still reach the wrapped cause, e.g.
+// errors.As into *exec.ExitError or errors.Is against ErrAttemptTimeout.
+type ExitError struct {
+	Cause error
+	Class ExitClass
+}
+
+func (e *ExitError) Error() string {
+	return e.Cause.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Cause
+}
+
+// ClassifyExitError inspects err, as returned by cmd.Run()/cmd.Wait() or by
+// withRetries once timeouts/cancellation are taken into account, and
+// classifies it - similar to the docker integration-cli getExitCode/isKilled
+// helpers: was the command not found, was it killed by a signal, did it just
+// exit non-zero, or did it not run to completion at all.
+func ClassifyExitError(err error) ExitClass {
+	if err == nil {
+		return ExitSuccess
+	}
+
+	switch {
+	case errors.Is(err, ErrAttemptTimeout):
+		return ExitAttemptTimeout
+	case errors.Is(err, ErrTotalTimeout):
+		return ExitTotalTimeout
+	case errors.Is(err, context.Canceled):
+		return ExitContextCanceled
+	}
+
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+		return ExitCommandNotFound
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			return ExitSignaled
+		}
+		return ExitNonZero
+	}
+
+	return ExitUnknown
+}
+
+// populateExitStatus fills in Result's Signaled/Signal/CoreDump/ExitCode from
+// cmd, after it has run. ExitCode is -1 if the process never started, falling
+// back to 127 (the conventional "command not found" shell exit code) when we
+// know that's why.
+func populateExitStatus(result *Result, cmd *exec.Cmd, runErr error) {
+	if cmd.ProcessState == nil {
+		result.ExitCode = -1
+		if ClassifyExitError(runErr) == ExitCommandNotFound {
+			result.ExitCode = 127
+		}
+		return
+	}
+
+	result.ExitCode = cmd.ProcessState.ExitCode()
+
+	ws, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return
+	}
+
+	result.Signaled = true
+	result.Signal = ws.Signal()
+	result.CoreDump = ws.CoreDump()
+}
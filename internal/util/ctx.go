@@ -30,3 +30,10 @@ func NewResetWriter(w io.Writer, resetFunc ResetFunc) ResetWriter {
 func NewResetWriterCh(w io.Writer, resetChan chan any) ResetWriter {
 	return NewResetWriter(w, func() { resetChan <- struct{}{} })
 }
+
+// NewSignalForwarderWriter returns a writer that discards everything written to it,
+// but forwards a signal on ch for every Write call. It is used to reset attempt
+// timeouts on output without needing to duplicate the bytes anywhere.
+func NewSignalForwarderWriter(ch chan any) ResetWriter {
+	return NewResetWriterCh(io.Discard, ch)
+}
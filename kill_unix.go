@@ -0,0 +1,38 @@
+//go:build !windows
+
+package cmder
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setupProcessGroup makes cmd the leader of a new process group, so that
+// sendSignal can reach the whole process tree it spawns rather than just the
+// leader itself.
+func setupProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// sendSignal delivers sig to cmd's whole process group. If that fails (e.g. the
+// group is already gone), it falls back to signalling the process directly.
+func sendSignal(cmd *exec.Cmd, sig os.Signal) error {
+	if cmd.Process == nil {
+		return nil
+	}
+
+	unixSig, ok := sig.(syscall.Signal)
+	if !ok {
+		unixSig = syscall.SIGKILL
+	}
+
+	if err := syscall.Kill(-cmd.Process.Pid, unixSig); err != nil {
+		return cmd.Process.Signal(sig)
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package cmder
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setupProcessGroup is a no-op on Windows: there is no POSIX process group to
+// set up, so sendSignal always targets the process directly.
+func setupProcessGroup(cmd *exec.Cmd) {
+}
+
+// sendSignal ignores sig on Windows, which cannot deliver arbitrary signals to
+// another process, and always hard-kills it instead.
+func sendSignal(cmd *exec.Cmd, _ os.Signal) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
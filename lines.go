@@ -0,0 +1,45 @@
+package cmder
+
+import (
+	"bufio"
+	"io"
+)
+
+// lineForwarder turns writes into lines delivered over a channel, via an
+// internal io.Pipe + bufio.Scanner, mirroring the StdoutPipe+bufio.Scanner
+// pattern commonly used to tail long-running output line by line.
+type lineForwarder struct {
+	w    *io.PipeWriter
+	done chan struct{}
+}
+
+func newLineForwarder(ch chan<- string) *lineForwarder {
+	r, w := io.Pipe()
+	lf := &lineForwarder{w: w, done: make(chan struct{})}
+
+	go func() {
+		defer close(lf.done)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			ch <- scanner.Text()
+		}
+		_, _ = io.Copy(io.Discard, r) // drain in case the scanner bailed on a too-long line
+	}()
+
+	return lf
+}
+
+func (lf *lineForwarder) Write(p []byte) (int, error) {
+	return lf.w.Write(p)
+}
+
+// close ends this attempt's forwarding: it closes the internal pipe, which
+// makes the scanner goroutine flush any trailing unterminated line and stop,
+// then waits for that goroutine to finish. It does not touch the caller's
+// channel - callers get a fresh lineForwarder per attempt on retry, and the
+// channel itself is only closed once Spec.Run has no more attempts left.
+func (lf *lineForwarder) close() {
+	_ = lf.w.Close()
+	<-lf.done
+}
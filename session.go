@@ -0,0 +1,295 @@
+package cmder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GiGurra/cmder/internal/util"
+	"io"
+	"os/exec"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrExpectTimeout is returned by Session.Expect/ExpectString when the pattern
+// did not appear in the child's output before the given timeout elapsed.
+var ErrExpectTimeout = errors.New("cmder: expect timed out")
+
+// Session represents an interactively driven child process, started via Spec.Start.
+// Unlike Spec.Run, a Session does not buffer output internally for the caller to read
+// after the fact - instead stdout and stderr are combined into a rolling buffer that
+// Expect/ExpectString scan and consume from as matches are found.
+type Session struct {
+	spec   Spec
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	jobCtx context.Context
+	cancel context.CancelFunc
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	buf  bytes.Buffer
+
+	attemptDeadline atomic.Pointer[time.Time]
+
+	waitCh   chan struct{}
+	waitErr  error
+	waitExit int
+}
+
+// Start spawns the command with piped stdin/stdout/stderr instead of the buffered
+// io.Writer path used by Spec.Run, and returns a Session that can be driven
+// interactively with Expect/Send. The returned Session honors ctx and, if set,
+// Spec's TotalTimeout and AttemptTimeout (including ResetAttemptTimeoutOnOutput).
+func (c Spec) Start(ctx context.Context) (*Session, error) {
+
+	c.logBeforeRun()
+
+	jobCtx, cancelJobCtx := context.WithCancel(ctx)
+
+	if c.TotalTimeout > 0 {
+		executeAfterDuration(jobCtx, c.TotalTimeout, cancelJobCtx)
+	}
+
+	cmd := exec.CommandContext(jobCtx, c.App, c.Args...)
+	cmd.Dir = c.WorkingDirectory
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		cancelJobCtx()
+		return nil, fmt.Errorf("error creating stdin pipe for %s: %w", c.App, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancelJobCtx()
+		return nil, fmt.Errorf("error creating stdout pipe for %s: %w", c.App, err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		cancelJobCtx()
+		return nil, fmt.Errorf("error creating stderr pipe for %s: %w", c.App, err)
+	}
+
+	s := &Session{
+		spec:   c,
+		cmd:    cmd,
+		stdin:  stdin,
+		jobCtx: jobCtx,
+		cancel: cancelJobCtx,
+		waitCh: make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	s.attemptDeadline.Store(toPtr(time.Now().Add(c.AttemptTimeout)))
+
+	if err := cmd.Start(); err != nil {
+		cancelJobCtx()
+		return nil, fmt.Errorf("error starting cmd %s: %w", c.App, err)
+	}
+
+	// Reuse the same SignalForwarderWriter pathway Spec.Run uses, so
+	// ResetAttemptTimeoutOnOutput behaves identically in expect mode.
+	aliveSignal := make(chan any, 10)
+	sfw := util.NewSignalForwarderWriter(aliveSignal)
+
+	if c.AttemptTimeout > 0 {
+		var checkTimeoutFunc func()
+		checkTimeoutFunc = func() {
+			curDeadline := s.attemptDeadline.Load()
+			if time.Now().After(*curDeadline) {
+				cancelJobCtx()
+			} else {
+				executeAfterDuration(jobCtx, curDeadline.Sub(time.Now())+1*time.Millisecond, checkTimeoutFunc)
+			}
+		}
+		checkTimeoutFunc()
+	}
+
+	go func() {
+		for {
+			select {
+			case <-aliveSignal:
+				if c.ResetAttemptTimeoutOnOutput {
+					s.attemptDeadline.Store(toPtr(time.Now().Add(c.AttemptTimeout)))
+				}
+			case <-jobCtx.Done():
+				return
+			}
+		}
+	}()
+
+	// Wake any pending Expect when the job context is canceled, so it can
+	// observe the cancellation instead of blocking forever.
+	go func() {
+		<-jobCtx.Done()
+		s.mu.Lock()
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	}()
+
+	s.startReader(stdout, sfw)
+	s.startReader(stderr, sfw)
+
+	go s.run()
+
+	return s, nil
+}
+
+// startReader continuously reads from r, appends into the session's rolling buffer
+// and forwards the same bytes to tap (so ResetAttemptTimeoutOnOutput keeps working).
+func (s *Session) startReader(r io.Reader, tap io.Writer) {
+	go func() {
+		chunk := make([]byte, 4096)
+		for {
+			n, err := r.Read(chunk)
+			if n > 0 {
+				_, _ = tap.Write(chunk[:n])
+				s.mu.Lock()
+				s.buf.Write(chunk[:n])
+				s.cond.Broadcast()
+				s.mu.Unlock()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func (s *Session) run() {
+	err := s.cmd.Wait()
+
+	exitCode := 0
+	if s.cmd.ProcessState != nil {
+		exitCode = s.cmd.ProcessState.ExitCode()
+	} else if err != nil {
+		exitCode = -1
+	}
+
+	s.mu.Lock()
+	s.waitErr = err
+	s.waitExit = exitCode
+	s.mu.Unlock()
+
+	close(s.waitCh)
+
+	s.mu.Lock()
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	s.cancel()
+}
+
+// Expect scans the session's output buffer for pattern, blocking until it matches,
+// the process exits, or timeout elapses (timeout <= 0 means wait forever, bounded
+// only by the Session's ctx/TotalTimeout/AttemptTimeout). On match it returns the
+// full matched text and the pattern's submatch groups, and consumes everything up
+// to and including the match so subsequent Expect calls only see the tail.
+func (s *Session) Expect(pattern *regexp.Regexp, timeout time.Duration) (string, []string, error) {
+
+	var timedOut atomic.Bool
+
+	if timeout > 0 {
+		timer := time.AfterFunc(timeout, func() {
+			timedOut.Store(true)
+			s.mu.Lock()
+			s.cond.Broadcast()
+			s.mu.Unlock()
+		})
+		defer timer.Stop()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		if match, groups, ok := consumeMatch(&s.buf, pattern); ok {
+			return match, groups, nil
+		}
+
+		select {
+		case <-s.waitCh:
+			return "", nil, fmt.Errorf("expect %q on %s: %w", pattern.String(), s.spec.App, io.EOF)
+		default:
+		}
+
+		if timedOut.Load() {
+			return "", nil, fmt.Errorf("expect %q on %s: %w", pattern.String(), s.spec.App, ErrExpectTimeout)
+		}
+
+		if err := s.jobCtx.Err(); err != nil {
+			return "", nil, fmt.Errorf("expect %q on %s: %w", pattern.String(), s.spec.App, err)
+		}
+
+		s.cond.Wait()
+	}
+}
+
+// ExpectString is a convenience wrapper around Expect for plain-text matches.
+func (s *Session) ExpectString(str string, timeout time.Duration) error {
+	_, _, err := s.Expect(regexp.MustCompile(regexp.QuoteMeta(str)), timeout)
+	return err
+}
+
+// Send writes str to the child's stdin.
+func (s *Session) Send(str string) error {
+	_, err := io.WriteString(s.stdin, str)
+	return err
+}
+
+// SendLine writes str followed by a newline to the child's stdin.
+func (s *Session) SendLine(str string) error {
+	return s.Send(str + "\n")
+}
+
+// Close closes the session's stdin and tears down the child process.
+func (s *Session) Close() error {
+	err := s.stdin.Close()
+	s.cancel()
+	return err
+}
+
+// Wait blocks until the child process exits and returns its Result. Combined holds
+// whatever of the session's output buffer has not yet been consumed by Expect.
+func (s *Session) Wait() Result {
+	<-s.waitCh
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return Result{
+		Combined: s.buf.String(),
+		Err:      s.waitErr,
+		Attempts: 1,
+		ExitCode: s.waitExit,
+	}
+}
+
+// consumeMatch finds the first match of pattern in buf, removes everything up to
+// and including it, and returns the matched text and its submatch groups.
+func consumeMatch(buf *bytes.Buffer, pattern *regexp.Regexp) (match string, groups []string, ok bool) {
+	data := buf.Bytes()
+
+	loc := pattern.FindSubmatchIndex(data)
+	if loc == nil {
+		return "", nil, false
+	}
+
+	match = string(data[loc[0]:loc[1]])
+	for i := 2; i < len(loc); i += 2 {
+		if loc[i] < 0 {
+			groups = append(groups, "")
+			continue
+		}
+		groups = append(groups, string(data[loc[i]:loc[i+1]]))
+	}
+
+	rest := make([]byte, len(data)-loc[1])
+	copy(rest, data[loc[1]:])
+	buf.Reset()
+	buf.Write(rest)
+
+	return match, groups, true
+}
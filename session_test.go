@@ -0,0 +1,77 @@
+package cmder
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestSession_ExpectAndSend(t *testing.T) {
+	session, err := New("bash", "-c", `
+		echo -n "name? "
+		read name
+		echo "hello $name"
+	`).Start(context.Background())
+	if err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	if err := session.ExpectString("name? ", 5*time.Second); err != nil {
+		t.Fatalf("ExpectString() error: %v", err)
+	}
+
+	if err := session.SendLine("world"); err != nil {
+		t.Fatalf("SendLine() error: %v", err)
+	}
+
+	match, groups, err := session.Expect(regexp.MustCompile(`hello (\w+)`), 5*time.Second)
+	if err != nil {
+		t.Fatalf("Expect() error: %v", err)
+	}
+	if match != "hello world" {
+		t.Errorf("expected match 'hello world', got %q", match)
+	}
+	if len(groups) != 1 || groups[0] != "world" {
+		t.Errorf("expected groups [world], got %v", groups)
+	}
+
+	result := session.Wait()
+	if result.Err != nil {
+		t.Errorf("Wait() expected no error, got %v", result.Err)
+	}
+	if result.ExitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", result.ExitCode)
+	}
+}
+
+func TestSession_ExpectTimeout(t *testing.T) {
+	session, err := New("sleep", "5").Start(context.Background())
+	if err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	_, _, err = session.Expect(regexp.MustCompile(`never`), 200*time.Millisecond)
+	if !errors.Is(err, ErrExpectTimeout) {
+		t.Errorf("expected ErrExpectTimeout, got %v", err)
+	}
+}
+
+func TestSession_ExpectEOF(t *testing.T) {
+	session, err := New("echo", "done").Start(context.Background())
+	if err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	if err := session.ExpectString("done", 5*time.Second); err != nil {
+		t.Fatalf("ExpectString() error: %v", err)
+	}
+
+	if _, _, err := session.Expect(regexp.MustCompile(`never`), 2*time.Second); err == nil {
+		t.Error("expected an error once the process has exited without another match")
+	}
+}